@@ -1,41 +1,168 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
+
+	"github.com/tspython/wcanvas/tools/woff2-converter/emitgo"
+	"github.com/tspython/wcanvas/tools/woff2-converter/internal/pipeline"
+	"github.com/tspython/wcanvas/tools/woff2-converter/internal/sniff"
+	"github.com/tspython/wcanvas/tools/woff2-converter/pdfdef"
 )
 
-func main() {
+// cmdConvert implements `woff2-converter convert [flags] [input] [output]`,
+// the tool's original single-shot behavior.
+func cmdConvert(args []string) {
+	fs := flag.NewFlagSet("convert", flag.ExitOnError)
+	external := fs.Bool("external", false, "for WOFF2 input, shell out to woff2_decompress/fontTools instead of the built-in decoder")
+	index := fs.Int("index", 0, "face index to extract from a TTC collection")
+	force := fs.Bool("force", false, "re-emit TTF/OTF input instead of passing it through unchanged")
+	emitGoPkg := fs.String("emit-go", "", "also write the converted font as a Go source package at this path")
+	emitVar := fs.String("var", "TTF", "name of the []byte variable in the -emit-go package")
+	emitDesc := fs.String("desc", "", "description included in the -emit-go package doc comment")
+	maxBytes := fs.Int("max-bytes", 0, "split the -emit-go output across multiple .go files of at most this many bytes")
+	pdfDef := fs.Bool("pdf-def", false, "also write a gofpdf-compatible font-definition JSON file and .z glyph blob")
+	pdfName := fs.String("pdf-name", "", "font name for the -pdf-def output; defaults to the sfnt name table's full name")
+	encPath := fs.String("enc", "", "encoding map file for -pdf-def (see pdfdef.ParseEncodingMap); required with -pdf-def")
+	fs.Parse(args)
+
+	rest := fs.Args()
 	input := "../../data/fonts/Virgil.woff2"
-	if len(os.Args) > 1 {
-		input = os.Args[1]
+	if len(rest) > 0 {
+		input = rest[0]
 	}
 
 	output := strings.TrimSuffix(input, ".woff2") + ".ttf"
-	if len(os.Args) > 2 {
-		output = os.Args[2]
+	if len(rest) > 1 {
+		output = rest[1]
+	}
+
+	data, err := os.ReadFile(input)
+	if err != nil {
+		if os.IsNotExist(err) {
+			fmt.Printf("Error: %s not found\n", input)
+		} else {
+			fmt.Printf("Error: %v\n", err)
+		}
+		os.Exit(1)
 	}
 
-	if _, err := os.Stat(input); os.IsNotExist(err) {
-		fmt.Printf("Error: %s not found\n", input)
+	ttf, err := convert(data, input, output, *external, *index, *force)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
 	}
+	if ttf != nil {
+		if err := os.WriteFile(output, ttf, 0o644); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	fmt.Printf("Converted: %s\n", output)
+
+	if *emitGoPkg != "" {
+		if ttf == nil {
+			fmt.Println("Error: -emit-go requires the built-in converter, not -external")
+			os.Exit(1)
+		}
+		if err := emitgo.Generate(ttf, emitgo.Options{
+			PkgPath:  *emitGoPkg,
+			Var:      *emitVar,
+			Desc:     *emitDesc,
+			MaxBytes: *maxBytes,
+		}); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Wrote Go package: %s\n", *emitGoPkg)
+	}
+
+	if *pdfDef {
+		if ttf == nil {
+			fmt.Println("Error: -pdf-def requires the built-in converter, not -external")
+			os.Exit(1)
+		}
+		if *encPath == "" {
+			fmt.Println("Error: -pdf-def requires -enc <encoding.map>")
+			os.Exit(1)
+		}
+		if err := writePdfDef(ttf, output, *pdfName, *encPath); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+}
+
+// writePdfDef generates the gofpdf font-definition sidecar for ttf and
+// writes it, and its compressed glyph blob, next to output.
+func writePdfDef(ttf []byte, output, name, encPath string) error {
+	encFile, err := os.Open(encPath)
+	if err != nil {
+		return err
+	}
+	defer encFile.Close()
+	encMap, err := pdfdef.ParseEncodingMap(encFile)
+	if err != nil {
+		return err
+	}
+	encoding := strings.TrimSuffix(filepath.Base(encPath), filepath.Ext(encPath))
+
+	base := strings.TrimSuffix(output, filepath.Ext(output))
+	zPath := base + ".z"
+	jsonPath := base + ".json"
 
+	def, glyphData, err := pdfdef.Generate(ttf, name, encoding, encMap, filepath.Base(zPath))
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(zPath, glyphData, 0o644); err != nil {
+		return err
+	}
+	defJSON, err := def.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(jsonPath, defJSON, 0o644); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote PDF font def: %s, %s\n", jsonPath, zPath)
+	return nil
+}
+
+// convert classifies data by its magic bytes and dispatches to the matching
+// converter. It returns nil, nil when the external fallback already wrote
+// output itself.
+func convert(data []byte, input, output string, external bool, index int, force bool) ([]byte, error) {
+	if external && sniff.Kind(data) == sniff.WOFF2 {
+		if !convertExternal(input, output) {
+			return nil, fmt.Errorf("install: brew install woff2 or pip install fonttools")
+		}
+		return nil, nil
+	}
+	ttf, err := pipeline.Convert(data, index, force)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", input, err)
+	}
+	return ttf, nil
+}
+
+// convertExternal reproduces the old behavior: shell out to woff2_decompress
+// or, failing that, fontTools via python3. Kept behind -external for anyone
+// who wants to cross-check the native decoder against the reference tools.
+func convertExternal(input, output string) bool {
 	if tryCommand("woff2_decompress", input) {
 		expected := strings.TrimSuffix(input, ".woff2") + ".ttf"
 		if expected != output {
 			os.Rename(expected, output)
 		}
-	} else if tryPython(input, output) {
-		// success
-	} else {
-		fmt.Println("Install: brew install woff2 or pip install fonttools")
-		os.Exit(1)
+		return true
 	}
-
-	fmt.Printf("Converted: %s\n", output)
+	return tryPython(input, output)
 }
 
 func tryCommand(cmd string, args ...string) bool {
@@ -0,0 +1,231 @@
+// Package emitgo writes a converted font out as a Go source package,
+// analogous to how x/image/font/gofont ships pre-baked TrueType blobs. The
+// generated package needs no //go:embed and no shell scripts: importing it
+// is enough to get the font bytes at runtime.
+package emitgo
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"text/template"
+
+	"github.com/tspython/wcanvas/tools/woff2-converter/internal/nametable"
+	"github.com/tspython/wcanvas/tools/woff2-converter/internal/sfnt"
+)
+
+// Options controls the generated package.
+type Options struct {
+	// PkgPath is the directory the package is written into. The package
+	// name is derived from its base name.
+	PkgPath string
+	// Var is the name of the exported []byte variable holding the font
+	// data. Defaults to "TTF".
+	Var string
+	// Desc is a one-line description included in the package doc comment.
+	Desc string
+	// MaxBytes splits the font data across multiple .go files of at most
+	// this many bytes each, joined by a top-level Var = concat(...). Zero
+	// means no splitting.
+	MaxBytes int
+}
+
+// Generate writes ttf out as a Go source package per opts.
+func Generate(ttf []byte, opts Options) error {
+	if opts.Var == "" {
+		opts.Var = "TTF"
+	}
+	if err := os.MkdirAll(opts.PkgPath, 0o755); err != nil {
+		return err
+	}
+	pkgName := filepath.Base(opts.PkgPath)
+
+	_, tables, err := sfnt.Parse(ttf)
+	family, style := "", ""
+	if err == nil {
+		for _, t := range tables {
+			if t.Tag != "name" {
+				continue
+			}
+			names, nerr := nametable.Read(t.Data, nametable.Family, nametable.Subfamily)
+			if nerr == nil {
+				family = names[nametable.Family]
+				style = names[nametable.Subfamily]
+			}
+		}
+	}
+
+	chunks := splitBytes(ttf, opts.MaxBytes)
+
+	if len(chunks) == 1 {
+		src, err := renderSingle(pkgName, opts.Var, opts.Desc, family, style, chunks[0])
+		if err != nil {
+			return err
+		}
+		return writeFormatted(filepath.Join(opts.PkgPath, pkgName+".go"), src)
+	}
+
+	partVars := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		partVar := fmt.Sprintf("part%d", i)
+		partVars[i] = partVar
+		src, err := renderPart(pkgName, partVar, chunk)
+		if err != nil {
+			return err
+		}
+		path := filepath.Join(opts.PkgPath, fmt.Sprintf("%s_part%d.go", pkgName, i))
+		if err := writeFormatted(path, src); err != nil {
+			return err
+		}
+	}
+
+	src, err := renderConcat(pkgName, opts.Var, opts.Desc, family, style, partVars)
+	if err != nil {
+		return err
+	}
+	return writeFormatted(filepath.Join(opts.PkgPath, pkgName+".go"), src)
+}
+
+func splitBytes(data []byte, maxBytes int) [][]byte {
+	if maxBytes <= 0 || len(data) <= maxBytes {
+		return [][]byte{data}
+	}
+	var chunks [][]byte
+	for len(data) > 0 {
+		n := maxBytes
+		if n > len(data) {
+			n = len(data)
+		}
+		chunks = append(chunks, data[:n])
+		data = data[n:]
+	}
+	return chunks
+}
+
+const singleTemplate = `// Package {{.PkgName}} provides {{if .Family}}the {{.Family}}{{if .Style}} {{.Style}}{{end}} font{{else}}a converted font{{end}}, produced by wcanvas.
+{{if .Desc}}//
+// {{.Desc}}
+{{end}}package {{.PkgName}}
+
+{{if .Family}}// Family is the font family name parsed from the sfnt name table.
+const Family = {{printf "%q" .Family}}
+{{end}}{{if .Style}}
+// Style is the font style parsed from the sfnt name table.
+const Style = {{printf "%q" .Style}}
+{{end}}
+// {{.Var}} holds the raw sfnt (TTF/OTF) font data.
+var {{.Var}} = []byte{
+{{.Bytes}}}
+`
+
+const partTemplate = `package {{.PkgName}}
+
+var {{.Var}} = []byte{
+{{.Bytes}}}
+`
+
+const concatTemplate = `// Package {{.PkgName}} provides {{if .Family}}the {{.Family}}{{if .Style}} {{.Style}}{{end}} font{{else}}a converted font{{end}}, produced by wcanvas.
+{{if .Desc}}//
+// {{.Desc}}
+{{end}}package {{.PkgName}}
+
+{{if .Family}}// Family is the font family name parsed from the sfnt name table.
+const Family = {{printf "%q" .Family}}
+{{end}}{{if .Style}}
+// Style is the font style parsed from the sfnt name table.
+const Style = {{printf "%q" .Style}}
+{{end}}
+// {{.Var}} holds the raw sfnt (TTF/OTF) font data, assembled from the
+// {{.PkgName}}_part*.go files generated alongside this one.
+var {{.Var}} = concatParts({{.PartVars}})
+
+func concatParts(parts ...[]byte) []byte {
+	n := 0
+	for _, p := range parts {
+		n += len(p)
+	}
+	out := make([]byte, 0, n)
+	for _, p := range parts {
+		out = append(out, p...)
+	}
+	return out
+}
+`
+
+func renderSingle(pkgName, varName, desc, family, style string, data []byte) ([]byte, error) {
+	return render(singleTemplate, map[string]any{
+		"PkgName": pkgName,
+		"Var":     varName,
+		"Desc":    desc,
+		"Family":  family,
+		"Style":   style,
+		"Bytes":   byteLiteral(data),
+	})
+}
+
+func renderPart(pkgName, varName string, data []byte) ([]byte, error) {
+	return render(partTemplate, map[string]any{
+		"PkgName": pkgName,
+		"Var":     varName,
+		"Bytes":   byteLiteral(data),
+	})
+}
+
+func renderConcat(pkgName, varName, desc, family, style string, partVars []string) ([]byte, error) {
+	args := ""
+	for i, p := range partVars {
+		if i > 0 {
+			args += ", "
+		}
+		args += p
+	}
+	return render(concatTemplate, map[string]any{
+		"PkgName":  pkgName,
+		"Var":      varName,
+		"Desc":     desc,
+		"Family":   family,
+		"Style":    style,
+		"PartVars": args,
+	})
+}
+
+func render(tmpl string, data map[string]any) ([]byte, error) {
+	t, err := template.New("emitgo").Parse(tmpl)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// byteLiteral renders data as the body of a []byte composite literal, one
+// row of 12 bytes per line to keep generated files reviewable.
+func byteLiteral(data []byte) string {
+	var buf bytes.Buffer
+	for i, b := range data {
+		if i%12 == 0 {
+			buf.WriteByte('\t')
+		}
+		fmt.Fprintf(&buf, "0x%02x, ", b)
+		if i%12 == 11 {
+			buf.WriteByte('\n')
+		}
+	}
+	if len(data)%12 != 0 {
+		buf.WriteByte('\n')
+	}
+	return buf.String()
+}
+
+func writeFormatted(path string, src []byte) error {
+	formatted, err := format.Source(src)
+	if err != nil {
+		return fmt.Errorf("emitgo: formatting %s: %w", path, err)
+	}
+	return os.WriteFile(path, formatted, 0o644)
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/tspython/wcanvas/tools/woff2-converter/fontfind"
+)
+
+// cmdFind implements `woff2-converter find <name>`: it walks the OS's
+// standard font directories and prints the path of the first installed
+// font whose family, style or full name matches name.
+func cmdFind(args []string) {
+	fs := flag.NewFlagSet("find", flag.ExitOnError)
+	fs.Parse(args)
+
+	rest := fs.Args()
+	if len(rest) != 1 {
+		usage("find", "<name>")
+	}
+	name := rest[0]
+
+	match, err := fontfind.Find(fontfind.Dirs(), name)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	style := match.Style
+	if style == "" {
+		style = "Regular"
+	}
+	fmt.Printf("%s (%s %s)\n", match.Path, match.Family, style)
+}
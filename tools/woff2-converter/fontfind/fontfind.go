@@ -0,0 +1,166 @@
+// Package fontfind locates an installed font on the local system by
+// walking the OS's standard font directories and matching against each
+// candidate's sfnt "name" table.
+package fontfind
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/tspython/wcanvas/tools/woff2-converter/internal/nametable"
+	"github.com/tspython/wcanvas/tools/woff2-converter/internal/sfnt"
+	"github.com/tspython/wcanvas/tools/woff2-converter/internal/sniff"
+	"github.com/tspython/wcanvas/tools/woff2-converter/ttc"
+)
+
+// Match is a font file located by Find, along with the family/style names
+// read from its sfnt "name" table.
+type Match struct {
+	Path   string
+	Family string
+	Style  string
+}
+
+// Dirs returns the font directories to search for the current OS, in the
+// order they should be checked: user-specific locations before system-wide
+// ones. Directories that don't apply to this OS (e.g. an unset Windows
+// environment variable) are omitted rather than guessed at.
+func Dirs() []string {
+	home, _ := os.UserHomeDir()
+
+	var dirs []string
+	switch runtime.GOOS {
+	case "darwin":
+		if home != "" {
+			dirs = append(dirs, filepath.Join(home, "Library", "Fonts"))
+		}
+		dirs = append(dirs, "/Library/Fonts", "/System/Library/Fonts")
+	case "windows":
+		if local := os.Getenv("LOCALAPPDATA"); local != "" {
+			dirs = append(dirs, filepath.Join(local, "Microsoft", "Windows", "Fonts"))
+		}
+		if winDir := os.Getenv("WINDIR"); winDir != "" {
+			dirs = append(dirs, filepath.Join(winDir, "Fonts"))
+		}
+	default: // Linux and other Unix-likes.
+		if home != "" {
+			dirs = append(dirs, filepath.Join(home, ".local", "share", "fonts"))
+		}
+		dirs = append(dirs, "/usr/share/fonts")
+	}
+	return dirs
+}
+
+// Find walks dirs looking for a font whose family, style or full name
+// contains query (case-insensitive). It returns the first match, searching
+// directories in the order given.
+func Find(dirs []string, query string) (Match, error) {
+	needle := strings.ToLower(query)
+	for _, dir := range dirs {
+		if match, ok := findInDir(dir, needle); ok {
+			return match, nil
+		}
+	}
+	return Match{}, fmt.Errorf("fontfind: no installed font matches %q", query)
+}
+
+func findInDir(dir, needle string) (Match, bool) {
+	var match Match
+	found := false
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !isFontFile(path) {
+			return nil
+		}
+		for _, face := range readFaces(path) {
+			if matches(face, needle) {
+				match = Match{Path: path, Family: face.family, Style: face.style}
+				found = true
+				return filepath.SkipAll
+			}
+		}
+		return nil
+	})
+	return match, found
+}
+
+func isFontFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".ttf", ".otf", ".ttc":
+		return true
+	default:
+		return false
+	}
+}
+
+type face struct {
+	family, style, full string
+}
+
+func matches(f face, needle string) bool {
+	for _, candidate := range []string{f.family, f.style, f.full, f.family + " " + f.style} {
+		if strings.Contains(strings.ToLower(candidate), needle) {
+			return true
+		}
+	}
+	return false
+}
+
+// readFaces reads every face's family/style/full name out of path, which
+// may be a standalone sfnt (TTF/OTF) or a TTC collection. It returns nil if
+// path can't be read or parsed as a font.
+func readFaces(path string) []face {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	switch sniff.Kind(data) {
+	case sniff.TTF, sniff.OTF:
+		if f, ok := readFace(data); ok {
+			return []face{f}
+		}
+	case sniff.TTC:
+		count, err := ttc.Count(data)
+		if err != nil {
+			return nil
+		}
+		faces := make([]face, 0, count)
+		for i := 0; i < count; i++ {
+			extracted, err := ttc.ExtractFace(data, i)
+			if err != nil {
+				continue
+			}
+			if f, ok := readFace(extracted); ok {
+				faces = append(faces, f)
+			}
+		}
+		return faces
+	}
+	return nil
+}
+
+func readFace(data []byte) (face, bool) {
+	_, tables, err := sfnt.Parse(data)
+	if err != nil {
+		return face{}, false
+	}
+	for _, t := range tables {
+		if t.Tag != "name" {
+			continue
+		}
+		names, err := nametable.Read(t.Data, nametable.Family, nametable.Subfamily, nametable.FullName)
+		if err != nil {
+			return face{}, false
+		}
+		return face{
+			family: names[nametable.Family],
+			style:  names[nametable.Subfamily],
+			full:   names[nametable.FullName],
+		}, true
+	}
+	return face{}, false
+}
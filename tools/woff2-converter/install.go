@@ -0,0 +1,130 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/tspython/wcanvas/tools/woff2-converter/install"
+	"github.com/tspython/wcanvas/tools/woff2-converter/internal/pipeline"
+	"github.com/tspython/wcanvas/tools/woff2-converter/internal/sniff"
+)
+
+// cmdInstall implements `woff2-converter install <file-or-url>`: it fetches
+// file-or-url (downloading it first if it's a URL), unzips it if it's a zip
+// archive, converts whatever font it finds to a plain TTF/OTF, and copies
+// the result into the per-user font directory for the current OS.
+func cmdInstall(args []string) {
+	fs := flag.NewFlagSet("install", flag.ExitOnError)
+	index := fs.Int("index", 0, "face index to extract from a TTC collection")
+	fs.Parse(args)
+
+	rest := fs.Args()
+	if len(rest) != 1 {
+		usage("install", "<file-or-url>")
+	}
+	src := rest[0]
+
+	data, name, err := fetch(src)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if isZip(data) {
+		if data, name, err = extractFont(data); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	ttf, err := pipeline.Convert(data, *index, false)
+	if err != nil {
+		fmt.Printf("Error: %s: %v\n", name, err)
+		os.Exit(1)
+	}
+
+	name = strings.TrimSuffix(name, filepath.Ext(name)) + ".ttf"
+	dst, err := install.File(name, ttf)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := install.RefreshCache(); err != nil {
+		fmt.Printf("Warning: font cache refresh failed: %v\n", err)
+	}
+
+	fmt.Printf("Installed: %s\n", dst)
+}
+
+// httpClient bounds how long a URL install can hang waiting on a slow or
+// stalled server.
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// fetch returns src's contents and base name, downloading it first if it
+// looks like an http(s) URL.
+func fetch(src string) ([]byte, string, error) {
+	if strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://") {
+		resp, err := httpClient.Get(src)
+		if err != nil {
+			return nil, "", err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, "", fmt.Errorf("%s: %s", src, resp.Status)
+		}
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, "", err
+		}
+		name := src
+		if u, err := url.Parse(src); err == nil && u.Path != "" {
+			name = filepath.Base(u.Path)
+		}
+		return data, name, nil
+	}
+
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, filepath.Base(src), nil
+}
+
+// isZip reports whether data starts with a zip local file header.
+func isZip(data []byte) bool {
+	return len(data) >= 4 && data[0] == 'P' && data[1] == 'K' && data[2] == 0x03 && data[3] == 0x04
+}
+
+// extractFont returns the first recognized font file inside a zip archive,
+// along with its name.
+func extractFont(data []byte) ([]byte, string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, "", err
+	}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			continue
+		}
+		contents, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			continue
+		}
+		if sniff.Kind(contents) != sniff.Unknown {
+			return contents, filepath.Base(f.Name), nil
+		}
+	}
+	return nil, "", fmt.Errorf("zip archive contains no recognized font file")
+}
@@ -0,0 +1,67 @@
+// Package install copies a converted font into the current user's font
+// directory and refreshes the OS font cache, so a newly installed face is
+// immediately available to other applications.
+package install
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+)
+
+// Dir returns the per-user font installation directory for the current OS,
+// creating it if it doesn't already exist.
+func Dir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	var dir string
+	switch runtime.GOOS {
+	case "darwin":
+		dir = filepath.Join(home, "Library", "Fonts")
+	case "windows":
+		local := os.Getenv("LOCALAPPDATA")
+		if local == "" {
+			local = filepath.Join(home, "AppData", "Local")
+		}
+		dir = filepath.Join(local, "Microsoft", "Windows", "Fonts")
+	default: // Linux and other Unix-likes.
+		dir = filepath.Join(home, ".local", "share", "fonts")
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// File writes data into the per-user font directory under name and returns
+// the path it was installed to.
+func File(name string, data []byte) (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	dst := filepath.Join(dir, filepath.Base(name))
+	if err := os.WriteFile(dst, data, 0o644); err != nil {
+		return "", err
+	}
+	return dst, nil
+}
+
+// RefreshCache refreshes the system font cache on platforms that need one:
+// fc-cache on Linux and other fontconfig systems. macOS and Windows pick up
+// fonts from the per-user directory without a separate refresh step, so
+// this is a no-op there. It's also a no-op if fc-cache isn't installed.
+func RefreshCache() error {
+	if runtime.GOOS != "linux" {
+		return nil
+	}
+	if _, err := exec.LookPath("fc-cache"); err != nil {
+		return nil
+	}
+	return exec.Command("fc-cache", "-f").Run()
+}
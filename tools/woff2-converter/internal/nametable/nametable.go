@@ -0,0 +1,105 @@
+// Package nametable reads the handful of name IDs from an sfnt "name"
+// table that tools care about: family, style, full name and PostScript
+// name.
+package nametable
+
+import (
+	"encoding/binary"
+	"errors"
+	"unicode/utf16"
+)
+
+// Name IDs, per the OpenType spec.
+const (
+	Family         = 1
+	Subfamily      = 2
+	FullName       = 4
+	PostScriptName = 6
+)
+
+// Read decodes a "name" table and returns the requested name IDs, preferring
+// the Windows (platform 3) platform, then Macintosh (platform 1), then
+// whatever's first. Missing IDs are simply absent from the result.
+func Read(data []byte, ids ...uint16) (map[uint16]string, error) {
+	if len(data) < 6 {
+		return nil, errors.New("nametable: table too short")
+	}
+	count := binary.BigEndian.Uint16(data[2:4])
+	storageOffset := binary.BigEndian.Uint16(data[4:6])
+
+	type candidate struct {
+		platformID uint16
+		value      string
+	}
+	byID := make(map[uint16]candidate, len(ids))
+	want := make(map[uint16]bool, len(ids))
+	for _, id := range ids {
+		want[id] = true
+	}
+
+	const recordSize = 12
+	for i := 0; i < int(count); i++ {
+		p := 6 + i*recordSize
+		if p+recordSize > len(data) {
+			break
+		}
+		platformID := binary.BigEndian.Uint16(data[p : p+2])
+		nameID := binary.BigEndian.Uint16(data[p+6 : p+8])
+		length := binary.BigEndian.Uint16(data[p+8 : p+10])
+		offset := binary.BigEndian.Uint16(data[p+10 : p+12])
+		if !want[nameID] {
+			continue
+		}
+		start := int(storageOffset) + int(offset)
+		end := start + int(length)
+		if start < 0 || end > len(data) {
+			continue
+		}
+		value := decodeNameString(platformID, data[start:end])
+		if value == "" {
+			continue
+		}
+		existing, ok := byID[nameID]
+		if !ok || betterPlatform(platformID, existing.platformID) {
+			byID[nameID] = candidate{platformID: platformID, value: value}
+		}
+	}
+
+	out := make(map[uint16]string, len(byID))
+	for id, c := range byID {
+		out[id] = c.value
+	}
+	return out, nil
+}
+
+// betterPlatform reports whether candidate should replace current: Windows
+// (3) beats everything, Macintosh (1) beats anything else.
+func betterPlatform(candidate, current uint16) bool {
+	rank := func(p uint16) int {
+		switch p {
+		case 3:
+			return 2
+		case 1:
+			return 1
+		default:
+			return 0
+		}
+	}
+	return rank(candidate) > rank(current)
+}
+
+func decodeNameString(platformID uint16, raw []byte) string {
+	switch platformID {
+	case 1: // Macintosh: treat as ASCII/Latin-1, good enough for common Roman names.
+		return string(raw)
+	default: // Windows (3) and Unicode (0) platforms store UTF-16BE.
+		if len(raw)%2 != 0 {
+			return ""
+		}
+		units := make([]uint16, len(raw)/2)
+		for i := range units {
+			units[i] = binary.BigEndian.Uint16(raw[i*2 : i*2+2])
+		}
+		return string(utf16.Decode(units))
+	}
+}
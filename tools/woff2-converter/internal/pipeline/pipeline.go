@@ -0,0 +1,43 @@
+// Package pipeline is the shared "turn arbitrary font bytes into a plain
+// sfnt" step used by both the convert and install subcommands.
+package pipeline
+
+import (
+	"fmt"
+
+	"github.com/tspython/wcanvas/tools/woff2-converter/internal/sfnt"
+	"github.com/tspython/wcanvas/tools/woff2-converter/internal/sniff"
+	"github.com/tspython/wcanvas/tools/woff2-converter/ttc"
+	"github.com/tspython/wcanvas/tools/woff2-converter/woff1"
+	"github.com/tspython/wcanvas/tools/woff2-converter/woff2"
+)
+
+// Convert classifies data by its magic bytes and decodes it into a plain
+// sfnt (TTF/OTF) byte stream. index selects a face when data is a TTC
+// collection; force re-emits TTF/OTF input (re-sorted tables, recomputed
+// checksums) instead of passing it through unchanged.
+func Convert(data []byte, index int, force bool) ([]byte, error) {
+	switch sniff.Kind(data) {
+	case sniff.WOFF1:
+		return woff1.Decode(data)
+
+	case sniff.WOFF2:
+		return woff2.Decode(data)
+
+	case sniff.TTC:
+		return ttc.ExtractFace(data, index)
+
+	case sniff.TTF, sniff.OTF:
+		if !force {
+			return data, nil
+		}
+		flavor, tables, err := sfnt.Parse(data)
+		if err != nil {
+			return nil, err
+		}
+		return sfnt.Build(flavor, tables), nil
+
+	default:
+		return nil, fmt.Errorf("unrecognized font format")
+	}
+}
@@ -0,0 +1,160 @@
+// Package sfnt builds a plain TTF/OTF byte stream from a set of decoded
+// tables. It is shared by the woff1 and woff2 decoders so the two formats
+// don't each reimplement sfnt directory layout and checksumming.
+package sfnt
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Table is a single decoded font table, keyed by its 4-byte tag.
+type Table struct {
+	Tag  string
+	Data []byte
+}
+
+// Parse reads the OffsetTable and TableDirectory of a plain sfnt (TTF/OTF)
+// byte stream, returning its flavor and tables. It does not follow TTC
+// collection headers; see package ttc for that.
+func Parse(data []byte) (flavor uint32, tables []Table, err error) {
+	return ParseAt(data, 0)
+}
+
+// ParseAt reads an OffsetTable and TableDirectory starting at offsetTable
+// within data, as used both for a standalone sfnt file (offsetTable 0) and
+// for one face of a TTC collection (offsetTable pointing into the shared
+// file). TableRecord offsets are always absolute within data.
+func ParseAt(data []byte, offsetTable int) (flavor uint32, tables []Table, err error) {
+	if offsetTable+12 > len(data) {
+		return 0, nil, errors.New("sfnt: file too short")
+	}
+	flavor = binary.BigEndian.Uint32(data[offsetTable : offsetTable+4])
+	numTables := binary.BigEndian.Uint16(data[offsetTable+4 : offsetTable+6])
+
+	dirStart := offsetTable + 12
+	tables = make([]Table, numTables)
+	for i := 0; i < int(numTables); i++ {
+		p := dirStart + i*16
+		if p+16 > len(data) {
+			return 0, nil, errors.New("sfnt: truncated table directory")
+		}
+		tag := string(data[p : p+4])
+		offset := binary.BigEndian.Uint32(data[p+8 : p+12])
+		length := binary.BigEndian.Uint32(data[p+12 : p+16])
+		if int(offset)+int(length) > len(data) {
+			return 0, nil, fmt.Errorf("sfnt: table %q runs past end of file", tag)
+		}
+		tables[i] = Table{Tag: tag, Data: data[offset : offset+length]}
+	}
+	return flavor, tables, nil
+}
+
+// Build assembles tables into a valid sfnt file: sorted TableRecord
+// entries, correct offsets/checksums, and a fixed head.checkSumAdjustment.
+// tables is sorted in place.
+func Build(flavor uint32, tables []Table) []byte {
+	sortTables(tables)
+
+	numTables := len(tables)
+	headerSize := 12 + 16*numTables
+	searchRange, entrySelector, rangeShift := DirectorySizing(numTables)
+
+	out := make([]byte, headerSize)
+	binary.BigEndian.PutUint32(out[0:4], flavor)
+	binary.BigEndian.PutUint16(out[4:6], uint16(numTables))
+	binary.BigEndian.PutUint16(out[6:8], searchRange)
+	binary.BigEndian.PutUint16(out[8:10], entrySelector)
+	binary.BigEndian.PutUint16(out[10:12], rangeShift)
+
+	offset := uint32(headerSize)
+	var headOffset, headDirPos, headLen uint32
+	for i, t := range tables {
+		p := 12 + 16*i
+		if t.Tag == "head" {
+			headOffset, headDirPos, headLen = offset, uint32(p), uint32(len(t.Data))
+		}
+		copy(out[p:p+4], t.Tag)
+		binary.BigEndian.PutUint32(out[p+4:p+8], headChecksum(t))
+		binary.BigEndian.PutUint32(out[p+8:p+12], offset)
+		binary.BigEndian.PutUint32(out[p+12:p+16], uint32(len(t.Data)))
+		offset += uint32(len(t.Data))
+		offset = (offset + 3) &^ 3 // tables are padded to a 4-byte boundary
+	}
+
+	for _, t := range tables {
+		out = append(out, t.Data...)
+		for len(out)%4 != 0 {
+			out = append(out, 0)
+		}
+	}
+
+	if headOffset != 0 {
+		FixCheckSumAdjustment(out, headOffset)
+		// The directory checksum above was computed with checkSumAdjustment
+		// zeroed (see headChecksum); FixCheckSumAdjustment has since written
+		// the real value into head's bytes, so recompute its entry now.
+		binary.BigEndian.PutUint32(out[headDirPos+4:headDirPos+8], Checksum(out[headOffset:headOffset+headLen]))
+	}
+	return out
+}
+
+// headChecksum computes a table's directory checksum, treating head's
+// checkSumAdjustment field (bytes 8-12) as zero: its real value isn't known
+// until the whole font is assembled, and the OpenType spec requires it be
+// excluded from head's own checksum.
+func headChecksum(t Table) uint32 {
+	if t.Tag != "head" || len(t.Data) < 12 {
+		return Checksum(t.Data)
+	}
+	data := append([]byte(nil), t.Data...)
+	binary.BigEndian.PutUint32(data[8:12], 0)
+	return Checksum(data)
+}
+
+func sortTables(tables []Table) {
+	for i := 1; i < len(tables); i++ {
+		for j := i; j > 0 && tables[j-1].Tag > tables[j].Tag; j-- {
+			tables[j-1], tables[j] = tables[j], tables[j-1]
+		}
+	}
+}
+
+// DirectorySizing computes the sfnt OffsetTable's searchRange,
+// entrySelector and rangeShift fields for numTables entries.
+func DirectorySizing(numTables int) (searchRange, entrySelector, rangeShift uint16) {
+	entries := 1
+	maxPow2 := 0
+	for entries*2 <= numTables {
+		entries *= 2
+		maxPow2++
+	}
+	searchRange = uint16(entries * 16)
+	entrySelector = uint16(maxPow2)
+	rangeShift = uint16(numTables*16) - searchRange
+	return
+}
+
+// Checksum computes the OpenType table checksum: the sum of the table's
+// bytes read as big-endian uint32s, zero-padded to a 4-byte boundary.
+func Checksum(data []byte) uint32 {
+	var sum uint32
+	padded := data
+	if len(padded)%4 != 0 {
+		padded = append(append([]byte(nil), padded...), make([]byte, 4-len(padded)%4)...)
+	}
+	for i := 0; i < len(padded); i += 4 {
+		sum += binary.BigEndian.Uint32(padded[i : i+4])
+	}
+	return sum
+}
+
+// FixCheckSumAdjustment recomputes the whole-font checksum and stores
+// 0xB1B0AFBA minus that checksum into head.checkSumAdjustment, per the
+// OpenType spec.
+func FixCheckSumAdjustment(font []byte, headOffset uint32) {
+	adjOffset := headOffset + 8
+	binary.BigEndian.PutUint32(font[adjOffset:adjOffset+4], 0)
+	binary.BigEndian.PutUint32(font[adjOffset:adjOffset+4], 0xB1B0AFBA-Checksum(font))
+}
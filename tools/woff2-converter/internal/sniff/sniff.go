@@ -0,0 +1,56 @@
+// Package sniff classifies font files by magic bytes so callers don't have
+// to trust a file extension.
+package sniff
+
+import "encoding/binary"
+
+// FontKind identifies the on-disk format of a font file.
+type FontKind int
+
+const (
+	Unknown FontKind = iota
+	WOFF1
+	WOFF2
+	TTF
+	OTF
+	TTC
+)
+
+func (k FontKind) String() string {
+	switch k {
+	case WOFF1:
+		return "WOFF1"
+	case WOFF2:
+		return "WOFF2"
+	case TTF:
+		return "TTF"
+	case OTF:
+		return "OTF"
+	case TTC:
+		return "TTC"
+	default:
+		return "unknown"
+	}
+}
+
+// Kind classifies data by its leading magic bytes. It returns Unknown if
+// data is too short or doesn't match any recognized font signature.
+func Kind(data []byte) FontKind {
+	if len(data) < 4 {
+		return Unknown
+	}
+	switch binary.BigEndian.Uint32(data[0:4]) {
+	case 0x774F4646: // 'wOFF'
+		return WOFF1
+	case 0x774F4632: // 'wOF2'
+		return WOFF2
+	case 0x00010000: // TTF version tag
+		return TTF
+	case 0x4F54544F: // 'OTTO'
+		return OTF
+	case 0x74746366: // 'ttcf'
+		return TTC
+	default:
+		return Unknown
+	}
+}
@@ -0,0 +1,31 @@
+// Command woff2-converter converts web font formats (WOFF/WOFF2/TTC) to
+// plain TTF/OTF, and can locate or install fonts on the local system.
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	args := os.Args[1:]
+
+	switch {
+	case len(args) > 0 && args[0] == "find":
+		cmdFind(args[1:])
+	case len(args) > 0 && args[0] == "install":
+		cmdInstall(args[1:])
+	case len(args) > 0 && args[0] == "convert":
+		cmdConvert(args[1:])
+	default:
+		// No recognized subcommand: treat args as convert's own flags and
+		// positional arguments, so `woff2-converter in.woff2 out.ttf` keeps
+		// working exactly as it always has.
+		cmdConvert(args)
+	}
+}
+
+func usage(cmd, args string) {
+	fmt.Fprintf(os.Stderr, "usage: woff2-converter %s %s\n", cmd, args)
+	os.Exit(2)
+}
@@ -0,0 +1,332 @@
+// Package pdfdef produces a gofpdf-compatible font-definition JSON file and
+// its companion zlib-compressed glyph data blob, mirroring what
+// jung-kurt/gofpdf's makefont tool emits. This lets a TTF converted by
+// wcanvas be dropped straight into a gofpdf (or similar) PDF/SVG renderer
+// without a separate Python/Go preprocessing step.
+package pdfdef
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/tspython/wcanvas/tools/woff2-converter/internal/nametable"
+	"github.com/tspython/wcanvas/tools/woff2-converter/internal/sfnt"
+)
+
+// ParseEncodingMap reads an 8-bit encoding map (e.g. -enc cp1252.map) and
+// returns the byte-code -> Unicode rune table it describes. Each
+// non-comment line holds whitespace-separated fields: a code point (decimal
+// or 0x-prefixed hex) and its Unicode value (decimal, 0x-prefixed hex, or
+// U+XXXX); anything after that is an optional glyph name comment. Blank
+// lines and lines starting with '#' are ignored. For example:
+//
+//	32   0x0020  space
+//	33   U+0021  exclam
+func ParseEncodingMap(r io.Reader) (map[int]rune, error) {
+	out := make(map[int]rune)
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("pdfdef: %d: expected \"<code> <unicode> [name]\"", line)
+		}
+		code, err := strconv.ParseInt(fields[0], 0, 32)
+		if err != nil {
+			return nil, fmt.Errorf("pdfdef: %d: bad code %q: %w", line, fields[0], err)
+		}
+		u, err := parseRune(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("pdfdef: %d: bad unicode value %q: %w", line, fields[1], err)
+		}
+		out[int(code)] = u
+	}
+	return out, scanner.Err()
+}
+
+func parseRune(s string) (rune, error) {
+	if rest, ok := strings.CutPrefix(strings.ToUpper(s), "U+"); ok {
+		v, err := strconv.ParseInt(rest, 16, 32)
+		return rune(v), err
+	}
+	v, err := strconv.ParseInt(s, 0, 32)
+	return rune(v), err
+}
+
+// Descriptor mirrors gofpdf's FontDescType: the handful of metrics a PDF
+// viewer needs to lay out and fake-render glyphs it doesn't have to hint.
+type Descriptor struct {
+	Ascent       int     `json:"Ascent"`
+	Descent      int     `json:"Descent"`
+	CapHeight    int     `json:"CapHeight"`
+	XHeight      int     `json:"XHeight"`
+	Flags        int     `json:"Flags"`
+	FontBBox     string  `json:"FontBBox"`
+	ItalicAngle  float64 `json:"ItalicAngle"`
+	StemV        int     `json:"StemV"`
+	MissingWidth int     `json:"MissingWidth"`
+}
+
+// Def mirrors gofpdf's FontDefType, the JSON structure its AddFont expects.
+type Def struct {
+	Type               string      `json:"Tp"`
+	Name               string      `json:"Name"`
+	Desc               Descriptor  `json:"Desc"`
+	UnderlinePosition  int         `json:"Up"`
+	UnderlineThickness int         `json:"Ut"`
+	CharWidths         map[int]int `json:"Cw"`
+	Encoding           string      `json:"Enc"`
+	File               string      `json:"File"`
+	OriginalSize       int         `json:"OriginalSize"`
+}
+
+// PDF font descriptor flag bits (PDF 32000-1:2008, table 123).
+const (
+	flagFixedPitch  = 1 << 0
+	flagNonsymbolic = 1 << 5
+	flagItalic      = 1 << 6
+)
+
+// Generate builds a Def and the zlib-compressed glyph data blob for ttf.
+// encoding names the target 8-bit code page (e.g. "cp1252"), and encMap is
+// the parsed code->rune table for that encoding (see ParseEncodingMap).
+// zFileName is the value written into Def.File, typically the basename of
+// wherever the caller writes the returned blob.
+func Generate(ttf []byte, name, encoding string, encMap map[int]rune, zFileName string) (*Def, []byte, error) {
+	_, tables, err := sfnt.Parse(ttf)
+	if err != nil {
+		return nil, nil, err
+	}
+	byTag := make(map[string][]byte, len(tables))
+	for _, t := range tables {
+		byTag[t.Tag] = t.Data
+	}
+	for _, req := range []string{"head", "hhea", "hmtx", "post", "cmap"} {
+		if byTag[req] == nil {
+			return nil, nil, fmt.Errorf("pdfdef: font is missing required table %q", req)
+		}
+	}
+
+	head := byTag["head"]
+	unitsPerEm := binary.BigEndian.Uint16(head[18:20])
+	macStyle := binary.BigEndian.Uint16(head[44:46])
+	scale := func(v int) int {
+		return int(math.Round(float64(v) * 1000 / float64(unitsPerEm)))
+	}
+	xMin := int(int16(binary.BigEndian.Uint16(head[36:38])))
+	yMin := int(int16(binary.BigEndian.Uint16(head[38:40])))
+	xMax := int(int16(binary.BigEndian.Uint16(head[40:42])))
+	yMax := int(int16(binary.BigEndian.Uint16(head[42:44])))
+	fontBBox := fmt.Sprintf("[%d %d %d %d]", scale(xMin), scale(yMin), scale(xMax), scale(yMax))
+
+	hhea := byTag["hhea"]
+	ascender := int(int16(binary.BigEndian.Uint16(hhea[4:6])))
+	descender := int(int16(binary.BigEndian.Uint16(hhea[6:8])))
+	numHMetrics := binary.BigEndian.Uint16(hhea[34:36])
+
+	advances, err := parseHmtx(byTag["hmtx"], numHMetrics)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	post := byTag["post"]
+	italicAngle := fixed16dot16(binary.BigEndian.Uint32(post[4:8]))
+	underlinePosition := int(int16(binary.BigEndian.Uint16(post[8:10])))
+	underlineThickness := int(int16(binary.BigEndian.Uint16(post[10:12])))
+	isFixedPitch := binary.BigEndian.Uint32(post[12:16]) != 0
+
+	capHeight, xHeight, stemV := scale(int(float64(unitsPerEm)*0.7)), scale(int(float64(unitsPerEm)*0.5)), 70
+	if os2 := byTag["OS/2"]; os2 != nil {
+		if version := binary.BigEndian.Uint16(os2[0:2]); version >= 2 && len(os2) >= 90 {
+			capHeight = scale(int(int16(binary.BigEndian.Uint16(os2[88:90]))))
+			xHeight = scale(int(int16(binary.BigEndian.Uint16(os2[86:88]))))
+		}
+		if len(os2) >= 6 {
+			weightClass := binary.BigEndian.Uint16(os2[4:6])
+			if weightClass >= 700 {
+				stemV = 120
+			}
+		}
+	}
+
+	flags := flagNonsymbolic
+	if isFixedPitch {
+		flags |= flagFixedPitch
+	}
+	if macStyle&0x02 != 0 || italicAngle != 0 {
+		flags |= flagItalic
+	}
+
+	glyphIndex, err := parseCmap(byTag["cmap"])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	missingWidth := scale(advances[0])
+	cw := make(map[int]int, len(encMap))
+	for code, r := range encMap {
+		gid, ok := glyphIndex[r]
+		if !ok {
+			continue
+		}
+		cw[code] = scale(advanceWidth(advances, gid))
+	}
+
+	names, _ := nametable.Read(byTag["name"], nametable.FullName, nametable.Family)
+	if name == "" {
+		name = names[nametable.FullName]
+	}
+	if name == "" {
+		name = names[nametable.Family]
+	}
+
+	def := &Def{
+		Type: "TrueType",
+		Name: name,
+		Desc: Descriptor{
+			Ascent:       scale(ascender),
+			Descent:      scale(descender),
+			CapHeight:    capHeight,
+			XHeight:      xHeight,
+			Flags:        flags,
+			FontBBox:     fontBBox,
+			ItalicAngle:  italicAngle,
+			StemV:        stemV,
+			MissingWidth: missingWidth,
+		},
+		UnderlinePosition:  scale(underlinePosition),
+		UnderlineThickness: scale(underlineThickness),
+		CharWidths:         cw,
+		Encoding:           encoding,
+		File:               zFileName,
+		OriginalSize:       len(ttf),
+	}
+
+	var z bytes.Buffer
+	w := zlib.NewWriter(&z)
+	if _, err := w.Write(ttf); err != nil {
+		return nil, nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, nil, err
+	}
+
+	return def, z.Bytes(), nil
+}
+
+// MarshalJSON renders def the way gofpdf expects: pretty-printed, so it's
+// easy to diff and hand-edit.
+func (d *Def) MarshalJSON() ([]byte, error) {
+	type alias Def
+	return json.MarshalIndent((*alias)(d), "", "  ")
+}
+
+func parseHmtx(data []byte, numHMetrics uint16) ([]int, error) {
+	if int(numHMetrics)*4 > len(data) {
+		return nil, errors.New("pdfdef: hmtx table shorter than numberOfHMetrics implies")
+	}
+	advances := make([]int, numHMetrics)
+	for i := range advances {
+		advances[i] = int(binary.BigEndian.Uint16(data[i*4 : i*4+2]))
+	}
+	return advances, nil
+}
+
+// advanceWidth returns the advance width of glyph gid: glyphs beyond the
+// last hmtx entry repeat the final advance, per the sfnt spec.
+func advanceWidth(advances []int, gid uint16) int {
+	if len(advances) == 0 {
+		return 0
+	}
+	if int(gid) < len(advances) {
+		return advances[gid]
+	}
+	return advances[len(advances)-1]
+}
+
+func fixed16dot16(raw uint32) float64 {
+	return float64(int32(raw)) / 65536
+}
+
+// parseCmap reads a "cmap" table and returns a rune->glyph index map, using
+// the first Windows Unicode BMP (platform 3, encoding 1, format 4)
+// subtable it finds.
+func parseCmap(data []byte) (map[rune]uint16, error) {
+	if len(data) < 4 {
+		return nil, errors.New("pdfdef: cmap table too short")
+	}
+	numTables := binary.BigEndian.Uint16(data[2:4])
+	var subtableOffset uint32
+	for i := 0; i < int(numTables); i++ {
+		p := 4 + i*8
+		if p+8 > len(data) {
+			break
+		}
+		platformID := binary.BigEndian.Uint16(data[p : p+2])
+		encodingID := binary.BigEndian.Uint16(data[p+2 : p+4])
+		offset := binary.BigEndian.Uint32(data[p+4 : p+8])
+		if platformID == 3 && encodingID == 1 {
+			subtableOffset = offset
+			break
+		}
+	}
+	if subtableOffset == 0 {
+		return nil, errors.New("pdfdef: no Windows Unicode BMP cmap subtable found")
+	}
+	sub := data[subtableOffset:]
+	if len(sub) < 14 || binary.BigEndian.Uint16(sub[0:2]) != 4 {
+		return nil, errors.New("pdfdef: only cmap format 4 is supported")
+	}
+
+	segCountX2 := binary.BigEndian.Uint16(sub[6:8])
+	segCount := int(segCountX2 / 2)
+	endCodes := sub[14:]
+	startCodes := endCodes[segCountX2+2:]
+	idDeltas := startCodes[segCountX2:]
+	idRangeOffsets := idDeltas[segCountX2:]
+
+	result := make(map[rune]uint16)
+	for s := 0; s < segCount; s++ {
+		end := binary.BigEndian.Uint16(endCodes[s*2 : s*2+2])
+		start := binary.BigEndian.Uint16(startCodes[s*2 : s*2+2])
+		delta := int16(binary.BigEndian.Uint16(idDeltas[s*2 : s*2+2]))
+		rangeOffset := binary.BigEndian.Uint16(idRangeOffsets[s*2 : s*2+2])
+		if start == 0xFFFF && end == 0xFFFF {
+			continue
+		}
+		for c := uint32(start); c <= uint32(end); c++ {
+			var gid uint16
+			if rangeOffset == 0 {
+				gid = uint16(int32(c) + int32(delta))
+			} else {
+				idx := s*2 + int(rangeOffset) + int(c-uint32(start))*2
+				if idx+2 > len(idRangeOffsets) {
+					continue
+				}
+				raw := binary.BigEndian.Uint16(idRangeOffsets[idx : idx+2])
+				if raw == 0 {
+					continue
+				}
+				gid = uint16(int32(raw) + int32(delta))
+			}
+			if gid != 0 {
+				result[rune(c)] = gid
+			}
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,48 @@
+// Package ttc extracts a single sfnt face out of a TrueType/OpenType font
+// collection (TTC) file.
+package ttc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/tspython/wcanvas/tools/woff2-converter/internal/sfnt"
+)
+
+const signature = 0x74746366 // 'ttcf'
+
+// Count returns the number of faces in a TTC file.
+func Count(data []byte) (int, error) {
+	if len(data) < 16 || binary.BigEndian.Uint32(data[0:4]) != signature {
+		return 0, errors.New("ttc: bad signature")
+	}
+	return int(binary.BigEndian.Uint32(data[8:12])), nil
+}
+
+// ExtractFace pulls face number index (0-based) out of a TTC file and
+// rebuilds it as a standalone sfnt (TTF/OTF) byte stream.
+func ExtractFace(data []byte, index int) ([]byte, error) {
+	numFonts, err := Count(data)
+	if err != nil {
+		return nil, err
+	}
+	if index < 0 || index >= numFonts {
+		return nil, fmt.Errorf("ttc: face index %d out of range, collection has %d faces", index, numFonts)
+	}
+
+	offsetTablePos := 12 + index*4
+	if offsetTablePos+4 > len(data) {
+		return nil, errors.New("ttc: truncated collection header")
+	}
+	off := binary.BigEndian.Uint32(data[offsetTablePos : offsetTablePos+4])
+	if int(off)+12 > len(data) {
+		return nil, errors.New("ttc: face offset table out of range")
+	}
+
+	flavor, tables, err := sfnt.ParseAt(data, int(off))
+	if err != nil {
+		return nil, fmt.Errorf("ttc: face %d: %w", index, err)
+	}
+	return sfnt.Build(flavor, tables), nil
+}
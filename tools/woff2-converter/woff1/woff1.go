@@ -0,0 +1,86 @@
+// Package woff1 decodes WOFF (version 1) font files into plain sfnt
+// (TTF/OTF) data. Unlike WOFF2, WOFF1 has a straightforward header and
+// table directory and compresses each table independently with zlib, so no
+// transform reconstruction is needed.
+package woff1
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/tspython/wcanvas/tools/woff2-converter/internal/sfnt"
+)
+
+const signature = 0x774F4646 // 'wOFF'
+
+type tableDirEntry struct {
+	tag        string
+	offset     uint32
+	compLength uint32
+	origLength uint32
+}
+
+// Decode converts a WOFF1 byte stream into a plain sfnt (TTF/OTF) byte
+// stream.
+func Decode(data []byte) ([]byte, error) {
+	if len(data) < 44 {
+		return nil, errors.New("woff1: file too short")
+	}
+	if binary.BigEndian.Uint32(data[0:4]) != signature {
+		return nil, errors.New("woff1: bad signature")
+	}
+
+	flavor := binary.BigEndian.Uint32(data[4:8])
+	numTables := binary.BigEndian.Uint16(data[12:14])
+
+	const dirStart = 44
+	const entrySize = 20
+	dirEnd := dirStart + int(numTables)*entrySize
+	if dirEnd > len(data) {
+		return nil, errors.New("woff1: truncated table directory")
+	}
+
+	entries := make([]tableDirEntry, numTables)
+	for i := 0; i < int(numTables); i++ {
+		p := dirStart + i*entrySize
+		entries[i] = tableDirEntry{
+			tag:        string(data[p : p+4]),
+			offset:     binary.BigEndian.Uint32(data[p+4 : p+8]),
+			compLength: binary.BigEndian.Uint32(data[p+8 : p+12]),
+			origLength: binary.BigEndian.Uint32(data[p+12 : p+16]),
+		}
+	}
+
+	tables := make([]sfnt.Table, numTables)
+	for i, e := range entries {
+		if int(e.offset)+int(e.compLength) > len(data) {
+			return nil, fmt.Errorf("woff1: table %q runs past end of file", e.tag)
+		}
+		raw := data[e.offset : e.offset+e.compLength]
+
+		var out []byte
+		if e.compLength == e.origLength {
+			out = raw
+		} else {
+			r, err := zlib.NewReader(bytes.NewReader(raw))
+			if err != nil {
+				return nil, fmt.Errorf("woff1: table %q: %w", e.tag, err)
+			}
+			out, err = io.ReadAll(r)
+			r.Close()
+			if err != nil {
+				return nil, fmt.Errorf("woff1: table %q: %w", e.tag, err)
+			}
+		}
+		if uint32(len(out)) != e.origLength {
+			return nil, fmt.Errorf("woff1: table %q decompressed to %d bytes, want %d", e.tag, len(out), e.origLength)
+		}
+		tables[i] = sfnt.Table{Tag: e.tag, Data: out}
+	}
+
+	return sfnt.Build(flavor, tables), nil
+}
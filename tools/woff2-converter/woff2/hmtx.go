@@ -0,0 +1,86 @@
+package woff2
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// readNumHMetrics reads hhea's numberOfHMetrics field (the last field in
+// the table, at byte offset 34).
+func readNumHMetrics(hhea []byte) (uint16, error) {
+	if len(hhea) < 36 {
+		return 0, errors.New("woff2: hhea table too short")
+	}
+	return binary.BigEndian.Uint16(hhea[34:36]), nil
+}
+
+// reconstructHmtx rebuilds the original hmtx table from a transformed hmtx
+// table (transformation version 1), per the WOFF2 spec. The transform
+// drops the left side bearing arrays whenever they're redundant with a
+// glyph's xMin, so xMins (as returned by reconstructGlyf) supplies the
+// values that weren't stored explicitly.
+func reconstructHmtx(data []byte, numGlyphs, numHMetrics uint16, xMins []int16) ([]byte, error) {
+	r := &byteReader{buf: data}
+	flags, err := r.u8()
+	if err != nil {
+		return nil, err
+	}
+	if flags&0xFC != 0 {
+		return nil, errors.New("woff2: hmtx transform flags have reserved bits set")
+	}
+	hasProportionalLSBs := flags&1 == 0
+	hasMonospaceLSBs := flags&2 == 0
+	if hasProportionalLSBs && hasMonospaceLSBs {
+		return nil, errors.New("woff2: hmtx claims a transform but omits neither lsb array")
+	}
+
+	if numHMetrics > numGlyphs {
+		return nil, fmt.Errorf("woff2: hmtx numberOfHMetrics %d exceeds numGlyphs %d", numHMetrics, numGlyphs)
+	}
+	if numHMetrics < 1 {
+		return nil, errors.New("woff2: hmtx numberOfHMetrics must be at least 1")
+	}
+
+	advanceWidths := make([]uint16, numHMetrics)
+	for i := range advanceWidths {
+		advanceWidths[i], err = r.u16()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	lsbs := make([]int16, numGlyphs)
+	for i := uint16(0); i < numHMetrics; i++ {
+		if hasProportionalLSBs {
+			lsbs[i], err = r.i16()
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			lsbs[i] = xMins[i]
+		}
+	}
+	for i := numHMetrics; i < numGlyphs; i++ {
+		if hasMonospaceLSBs {
+			lsbs[i], err = r.i16()
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			lsbs[i] = xMins[i]
+		}
+	}
+
+	out := make([]byte, 2*int(numGlyphs)+2*int(numHMetrics))
+	pos := 0
+	for i := uint16(0); i < numGlyphs; i++ {
+		if i < numHMetrics {
+			binary.BigEndian.PutUint16(out[pos:pos+2], advanceWidths[i])
+			pos += 2
+		}
+		binary.BigEndian.PutUint16(out[pos:pos+2], uint16(lsbs[i]))
+		pos += 2
+	}
+	return out, nil
+}
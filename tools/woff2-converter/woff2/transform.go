@@ -0,0 +1,563 @@
+package woff2
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// untransform walks the decoded table list and reconstructs whichever of
+// the two defined WOFF2 table transforms are present: glyf (with its
+// companion loca) and hmtx. All other tables pass through unchanged.
+func untransform(tables []sfntTable) ([]sfntTable, error) {
+	glyfIdx, locaIdx, hmtxIdx, hheaIdx := -1, -1, -1, -1
+	for i, t := range tables {
+		switch t.tag {
+		case "glyf":
+			glyfIdx = i
+		case "loca":
+			locaIdx = i
+		case "hmtx":
+			hmtxIdx = i
+		case "hhea":
+			hheaIdx = i
+		}
+	}
+
+	var xMins []int16
+	if glyfIdx != -1 {
+		if locaIdx == -1 {
+			return nil, errors.New("woff2: glyf table present without a loca table")
+		}
+		glyf, loca, mins, err := reconstructGlyf(tables[glyfIdx].data)
+		if err != nil {
+			return nil, fmt.Errorf("woff2: reconstructing glyf: %w", err)
+		}
+		tables[glyfIdx].data = glyf
+		tables[locaIdx].data = loca
+		xMins = mins
+	}
+
+	if hmtxIdx != -1 && tables[hmtxIdx].transformed {
+		if xMins == nil {
+			return nil, errors.New("woff2: hmtx table is transformed but no glyf table is present to supply xMin values")
+		}
+		if hheaIdx == -1 {
+			return nil, errors.New("woff2: hmtx table is transformed but no hhea table is present")
+		}
+		numHMetrics, err := readNumHMetrics(tables[hheaIdx].data)
+		if err != nil {
+			return nil, fmt.Errorf("woff2: reading hhea: %w", err)
+		}
+		hmtx, err := reconstructHmtx(tables[hmtxIdx].data, uint16(len(xMins)), numHMetrics, xMins)
+		if err != nil {
+			return nil, fmt.Errorf("woff2: reconstructing hmtx: %w", err)
+		}
+		tables[hmtxIdx].data = hmtx
+	}
+
+	return tables, nil
+}
+
+// byteReader is a tiny sequential cursor over a byte slice, used to walk
+// the several parallel streams that make up a transformed glyf table.
+type byteReader struct {
+	buf []byte
+	pos int
+	err error
+}
+
+func (r *byteReader) u8() (byte, error) {
+	if r.pos >= len(r.buf) {
+		return 0, errors.New("woff2: stream underrun")
+	}
+	b := r.buf[r.pos]
+	r.pos++
+	return b, nil
+}
+
+func (r *byteReader) u16() (uint16, error) {
+	if r.pos+2 > len(r.buf) {
+		return 0, errors.New("woff2: stream underrun")
+	}
+	v := binary.BigEndian.Uint16(r.buf[r.pos : r.pos+2])
+	r.pos += 2
+	return v, nil
+}
+
+func (r *byteReader) i16() (int16, error) {
+	v, err := r.u16()
+	return int16(v), err
+}
+
+func (r *byteReader) bytes(n int) ([]byte, error) {
+	if r.pos+n > len(r.buf) {
+		return nil, errors.New("woff2: stream underrun")
+	}
+	b := r.buf[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+// read255UShort decodes the variable-length 255UInt16 packing used
+// throughout the transformed glyf table (nPoints, composite/instruction
+// lengths, ...).
+func (r *byteReader) read255UShort() (uint16, error) {
+	const (
+		wordCode         = 253
+		oneMoreByteCode2 = 254
+		oneMoreByteCode1 = 255
+		lowestUCode      = 253
+	)
+	b1, err := r.u8()
+	if err != nil {
+		return 0, err
+	}
+	switch b1 {
+	case wordCode:
+		return r.u16()
+	case oneMoreByteCode1:
+		b2, err := r.u8()
+		if err != nil {
+			return 0, err
+		}
+		return uint16(b2) + lowestUCode, nil
+	case oneMoreByteCode2:
+		b2, err := r.u8()
+		if err != nil {
+			return 0, err
+		}
+		return uint16(b2) + 2*lowestUCode, nil
+	default:
+		return uint16(b1), nil
+	}
+}
+
+type point struct {
+	x, y    int32
+	onCurve bool
+}
+
+// reconstructGlyf rebuilds the original glyf and loca tables from a
+// transformed glyf table (transformation version 0), per the WOFF2 spec. It
+// also returns each glyph's xMin (0 for composite and empty glyphs), which
+// the hmtx transform needs to fill in left side bearings it didn't store
+// explicitly.
+func reconstructGlyf(data []byte) (glyfOut, locaOut []byte, xMins []int16, err error) {
+	hdr := &byteReader{buf: data}
+	if _, err = hdr.u16(); err != nil { // version
+		return nil, nil, nil, err
+	}
+	numGlyphs, err := hdr.u16()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	indexFormat, err := hdr.u16()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var sizes [7]uint32
+	for i := range sizes {
+		if hdr.pos+4 > len(hdr.buf) {
+			return nil, nil, nil, errors.New("woff2: truncated glyf transform header")
+		}
+		sizes[i] = binary.BigEndian.Uint32(hdr.buf[hdr.pos : hdr.pos+4])
+		hdr.pos += 4
+	}
+
+	nContourStream := &byteReader{buf: mustSlice(hdr, sizes[0])}
+	nPointsStream := &byteReader{buf: mustSlice(hdr, sizes[1])}
+	flagStream := &byteReader{buf: mustSlice(hdr, sizes[2])}
+	glyphStream := &byteReader{buf: mustSlice(hdr, sizes[3])}
+	compositeStream := &byteReader{buf: mustSlice(hdr, sizes[4])}
+	bboxStreamRaw := mustSlice(hdr, sizes[5])
+	instructionStream := &byteReader{buf: mustSlice(hdr, sizes[6])}
+	if hdr.err != nil {
+		return nil, nil, nil, hdr.err
+	}
+
+	bboxBitmapLen := (int(numGlyphs) + 31) / 32 * 4
+	if bboxBitmapLen > len(bboxStreamRaw) {
+		return nil, nil, nil, errors.New("woff2: bbox stream too short for bitmap")
+	}
+	bboxBitmap := bboxStreamRaw[:bboxBitmapLen]
+	bboxStream := &byteReader{buf: bboxStreamRaw[bboxBitmapLen:]}
+
+	glyfBuf := make([]byte, 0, len(data)*2)
+	loca := make([]uint32, numGlyphs+1)
+	xMins = make([]int16, numGlyphs)
+
+	for g := uint16(0); g < numGlyphs; g++ {
+		nContours, err := nContourStream.i16()
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		var glyphData []byte
+		hasBBox := bboxBitmap[g/8]&(0x80>>(g%8)) != 0
+
+		switch {
+		case nContours > 0:
+			var minX int16
+			glyphData, minX, err = buildSimpleGlyph(nContours, hasBBox, nPointsStream, flagStream, glyphStream, bboxStream, instructionStream)
+			xMins[g] = minX
+		case nContours == -1:
+			glyphData, err = buildCompositeGlyph(hasBBox, compositeStream, glyphStream, bboxStream, instructionStream)
+		case nContours == 0:
+			glyphData = nil
+		default:
+			err = fmt.Errorf("nContours %d not supported", nContours)
+		}
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("glyph %d: %w", g, err)
+		}
+
+		loca[g] = uint32(len(glyfBuf))
+		glyfBuf = append(glyfBuf, glyphData...)
+		for len(glyfBuf)%4 != 0 {
+			glyfBuf = append(glyfBuf, 0)
+		}
+	}
+	loca[numGlyphs] = uint32(len(glyfBuf))
+
+	if indexFormat == 0 {
+		locaOut = make([]byte, len(loca)*2)
+		for i, off := range loca {
+			binary.BigEndian.PutUint16(locaOut[i*2:i*2+2], uint16(off/2))
+		}
+	} else {
+		locaOut = make([]byte, len(loca)*4)
+		for i, off := range loca {
+			binary.BigEndian.PutUint32(locaOut[i*4:i*4+4], off)
+		}
+	}
+	return glyfBuf, locaOut, xMins, nil
+}
+
+// mustSlice pulls n bytes off r and records the first error encountered on
+// r.err, so a chain of these calls can be checked once at the end.
+func mustSlice(r *byteReader, n uint32) []byte {
+	if r.err != nil {
+		return nil
+	}
+	b, err := r.bytes(int(n))
+	if err != nil {
+		r.err = err
+		return nil
+	}
+	return b
+}
+
+// buildSimpleGlyph reconstructs one simple glyph from the parallel
+// nPoints/flag/glyph/bbox/instruction streams, and returns its xMin
+// alongside the encoded glyph (needed by the hmtx transform). Each
+// flagStream byte's high bit is the on/off-curve flag; the low 7 bits are
+// an index into the triplet table decoded by readTriplet, which determines
+// both the point's (dx, dy) and how many bytes it consumes from
+// glyphStream. This is the WOFF2-specific point encoding, distinct from the
+// classic glyf SimpleGlyph flag layout that encodeSimpleGlyph re-emits.
+func buildSimpleGlyph(nContours int16, hasExplicitBBox bool, nPointsStream, flagStream, glyphStream, bboxStream, instructionStream *byteReader) ([]byte, int16, error) {
+	endPts := make([]uint16, nContours)
+	total := 0
+	for i := range endPts {
+		n, err := nPointsStream.read255UShort()
+		if err != nil {
+			return nil, 0, err
+		}
+		total += int(n)
+		endPts[i] = uint16(total - 1)
+	}
+
+	flags := make([]byte, total)
+	for i := range flags {
+		f, err := flagStream.u8()
+		if err != nil {
+			return nil, 0, err
+		}
+		flags[i] = f
+	}
+
+	pts := make([]point, total)
+	x, y := int32(0), int32(0)
+	for i, f := range flags {
+		dx, dy, err := readTriplet(glyphStream, f)
+		if err != nil {
+			return nil, 0, err
+		}
+		x += dx
+		y += dy
+		pts[i] = point{x: x, y: y, onCurve: f&0x80 == 0}
+	}
+
+	instrLen, err := glyphStream.read255UShort()
+	if err != nil {
+		return nil, 0, err
+	}
+	instructions, err := instructionStream.bytes(int(instrLen))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	minX, minY, maxX, maxY := int16(0), int16(0), int16(0), int16(0)
+	if hasExplicitBBox {
+		minX, _ = bboxStream.i16()
+		minY, _ = bboxStream.i16()
+		maxX, _ = bboxStream.i16()
+		maxY, _ = bboxStream.i16()
+	} else {
+		minX, minY, maxX, maxY = boundingBox(pts)
+	}
+
+	return encodeSimpleGlyph(nContours, endPts, pts, instructions, minX, minY, maxX, maxY), minX, nil
+}
+
+// triplet bucket boundaries and per-point byte widths, per WOFF2 §5.1's
+// 128-entry triplet table: 10 flags encode y-only, 10 encode x-only, 64
+// encode both from a single extra byte, 36 use two extra bytes, 4 use
+// three, and the last 4 (flags 124-127) use four.
+const (
+	tripletYOnlyBound  = 10  // flags [0, 10): y-only, dx = 0
+	tripletXOnlyBound  = 20  // flags [10, 20): x-only, dy = 0
+	tripletOneByteBoth = 84  // flags [20, 84): 1 extra byte, both dx and dy
+	tripletTwoByte     = 120 // flags [84, 120): 2 extra bytes
+	tripletThreeByte   = 124 // flags [120, 124): 3 extra bytes
+)
+
+// withSign returns baseval negated unless flag's lowest bit is set,
+// matching the WOFF2 reference decoder's sign convention (bit set = positive).
+func withSign(flag byte, baseval int32) int32 {
+	if flag&1 != 0 {
+		return baseval
+	}
+	return -baseval
+}
+
+// readTriplet decodes one point's (dx, dy) from glyphStream given its flag
+// byte (high bit already stripped by the caller isn't required; only the
+// low 7 bits are used here), per the WOFF2 triplet encoding in §5.1.
+func readTriplet(stream *byteReader, flagByte byte) (dx, dy int32, err error) {
+	flag := flagByte & 0x7f
+
+	switch {
+	case flag < tripletYOnlyBound:
+		b0, err := stream.u8()
+		if err != nil {
+			return 0, 0, err
+		}
+		dy = withSign(flag, (int32(flag&14)<<7)+int32(b0))
+		return 0, dy, nil
+
+	case flag < tripletXOnlyBound:
+		b0, err := stream.u8()
+		if err != nil {
+			return 0, 0, err
+		}
+		dx = withSign(flag, (int32((flag-10)&14)<<7)+int32(b0))
+		return dx, 0, nil
+
+	case flag < tripletOneByteBoth:
+		b0, err := stream.u8()
+		if err != nil {
+			return 0, 0, err
+		}
+		b := int32(flag) - 20
+		dx = withSign(flag, 1+(b&0x30)+(int32(b0)>>4))
+		dy = withSign(flag>>1, 1+((b&0x0c)<<2)+(int32(b0)&0x0f))
+		return dx, dy, nil
+
+	case flag < tripletTwoByte:
+		b := int32(flag) - 84
+		bytes, err := stream.bytes(2)
+		if err != nil {
+			return 0, 0, err
+		}
+		dx = withSign(flag, 1+((b/12)<<8)+int32(bytes[0]))
+		dy = withSign(flag>>1, 1+(((b%12)>>2)<<8)+int32(bytes[1]))
+		return dx, dy, nil
+
+	case flag < tripletThreeByte:
+		bytes, err := stream.bytes(3)
+		if err != nil {
+			return 0, 0, err
+		}
+		dx = withSign(flag, (int32(bytes[0])<<4)+(int32(bytes[1])>>4))
+		dy = withSign(flag>>1, ((int32(bytes[1])&0x0f)<<8)+int32(bytes[2]))
+		return dx, dy, nil
+
+	default:
+		bytes, err := stream.bytes(4)
+		if err != nil {
+			return 0, 0, err
+		}
+		dx = withSign(flag, (int32(bytes[0])<<8)+int32(bytes[1]))
+		dy = withSign(flag>>1, (int32(bytes[2])<<8)+int32(bytes[3]))
+		return dx, dy, nil
+	}
+}
+
+func boundingBox(pts []point) (minX, minY, maxX, maxY int16) {
+	if len(pts) == 0 {
+		return 0, 0, 0, 0
+	}
+	minX, minY = int16(pts[0].x), int16(pts[0].y)
+	maxX, maxY = minX, minY
+	for _, p := range pts[1:] {
+		if int16(p.x) < minX {
+			minX = int16(p.x)
+		}
+		if int16(p.x) > maxX {
+			maxX = int16(p.x)
+		}
+		if int16(p.y) < minY {
+			minY = int16(p.y)
+		}
+		if int16(p.y) > maxY {
+			maxY = int16(p.y)
+		}
+	}
+	return
+}
+
+func encodeSimpleGlyph(nContours int16, endPts []uint16, pts []point, instructions []byte, minX, minY, maxX, maxY int16) []byte {
+	buf := make([]byte, 10)
+	binary.BigEndian.PutUint16(buf[0:2], uint16(nContours))
+	binary.BigEndian.PutUint16(buf[2:4], uint16(minX))
+	binary.BigEndian.PutUint16(buf[4:6], uint16(minY))
+	binary.BigEndian.PutUint16(buf[6:8], uint16(maxX))
+	binary.BigEndian.PutUint16(buf[8:10], uint16(maxY))
+
+	for _, e := range endPts {
+		var b [2]byte
+		binary.BigEndian.PutUint16(b[:], e)
+		buf = append(buf, b[:]...)
+	}
+
+	var il [2]byte
+	binary.BigEndian.PutUint16(il[:], uint16(len(instructions)))
+	buf = append(buf, il[:]...)
+	buf = append(buf, instructions...)
+
+	// Re-emit flags and coordinates in canonical (uncompressed) glyf form.
+	prevX, prevY := int32(0), int32(0)
+	flags := make([]byte, 0, len(pts))
+	xs := make([]byte, 0, len(pts)*2)
+	ys := make([]byte, 0, len(pts)*2)
+	for _, p := range pts {
+		var f byte
+		if p.onCurve {
+			f |= 0x01
+		}
+		dx := p.x - prevX
+		dy := p.y - prevY
+		prevX, prevY = p.x, p.y
+		f |= encodeCoordFlagAndAppend(dx, 0x02, 0x10, &xs)
+		f |= encodeCoordFlagAndAppend(dy, 0x04, 0x20, &ys)
+		flags = append(flags, f)
+	}
+	buf = append(buf, flags...)
+	buf = append(buf, xs...)
+	buf = append(buf, ys...)
+	return buf
+}
+
+// encodeCoordFlagAndAppend appends the short/same-sign encoding of a single
+// coordinate delta to out and returns the flag bits (shortBit, sameBit) to
+// OR into the point's flag byte, per the classic (non-transformed) glyf
+// coordinate encoding.
+func encodeCoordFlagAndAppend(delta int32, shortBit, sameBit byte, out *[]byte) byte {
+	if delta == 0 {
+		return sameBit
+	}
+	if delta > -256 && delta < 256 {
+		if delta < 0 {
+			*out = append(*out, byte(-delta))
+			return shortBit
+		}
+		*out = append(*out, byte(delta))
+		return shortBit | sameBit
+	}
+	var b [2]byte
+	binary.BigEndian.PutUint16(b[:], uint16(int16(delta)))
+	*out = append(*out, b[:]...)
+	return 0
+}
+
+const (
+	argsAreWords    = 0x0001
+	argsAreXYValues = 0x0002
+	weHaveAScale    = 0x0008
+	moreComponents  = 0x0020
+	weHaveXYScale   = 0x0040
+	weHaveTwoByTwo  = 0x0080
+	weHaveInstr     = 0x0100
+)
+
+func buildCompositeGlyph(hasExplicitBBox bool, compositeStream, glyphStream, bboxStream, instructionStream *byteReader) ([]byte, error) {
+	start := compositeStream.pos
+	var flags uint16
+	for {
+		f, err := compositeStream.u16()
+		if err != nil {
+			return nil, err
+		}
+		flags = f
+		if _, err := compositeStream.u16(); err != nil { // glyph index
+			return nil, err
+		}
+		argSize := 2
+		if flags&argsAreWords != 0 {
+			argSize = 4
+		}
+		if _, err := compositeStream.bytes(argSize); err != nil {
+			return nil, err
+		}
+		switch {
+		case flags&weHaveAScale != 0:
+			compositeStream.bytes(2)
+		case flags&weHaveXYScale != 0:
+			compositeStream.bytes(4)
+		case flags&weHaveTwoByTwo != 0:
+			compositeStream.bytes(8)
+		}
+		if flags&moreComponents == 0 {
+			break
+		}
+	}
+	compositeData := compositeStream.buf[start:compositeStream.pos]
+
+	var instructions []byte
+	if flags&weHaveInstr != 0 {
+		instrLen, err := glyphStream.read255UShort()
+		if err != nil {
+			return nil, err
+		}
+		instructions, err = instructionStream.bytes(int(instrLen))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	minX, minY, maxX, maxY := int16(0), int16(0), int16(0), int16(0)
+	if hasExplicitBBox {
+		minX, _ = bboxStream.i16()
+		minY, _ = bboxStream.i16()
+		maxX, _ = bboxStream.i16()
+		maxY, _ = bboxStream.i16()
+	}
+
+	buf := make([]byte, 10)
+	binary.BigEndian.PutUint16(buf[0:2], 0xFFFF) // -1 as uint16, composite glyph marker
+	binary.BigEndian.PutUint16(buf[2:4], uint16(minX))
+	binary.BigEndian.PutUint16(buf[4:6], uint16(minY))
+	binary.BigEndian.PutUint16(buf[6:8], uint16(maxX))
+	binary.BigEndian.PutUint16(buf[8:10], uint16(maxY))
+	buf = append(buf, compositeData...)
+	if flags&weHaveInstr != 0 {
+		var il [2]byte
+		binary.BigEndian.PutUint16(il[:], uint16(len(instructions)))
+		buf = append(buf, il[:]...)
+		buf = append(buf, instructions...)
+	}
+	return buf, nil
+}
@@ -0,0 +1,258 @@
+// Package woff2 decodes WOFF2 font files into plain sfnt (TTF/OTF) data
+// without shelling out to woff2_decompress or fontTools.
+package woff2
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/andybalholm/brotli"
+	"github.com/tspython/wcanvas/tools/woff2-converter/internal/sfnt"
+)
+
+const signature = 0x774F4632 // 'wOF2'
+
+// knownTags is the fixed table-tag dictionary used by the flags byte of a
+// TableDirectoryEntry (index 0-62). Index 63 means the tag follows as a
+// literal 4-byte value.
+var knownTags = [63]string{
+	"cmap", "head", "hhea", "hmtx", "maxp", "name", "OS/2", "post",
+	"cvt ", "fpgm", "glyf", "loca", "prep", "CFF ", "VORG", "EBDT",
+	"EBLC", "gasp", "hdmx", "kern", "LTSH", "PCLT", "VDMX", "vhea",
+	"vmtx", "BASE", "GDEF", "GPOS", "GSUB", "EBSC", "JSTF", "MATH",
+	"CBDT", "CBLC", "COLR", "CPAL", "SVG ", "sbix", "acnt", "avar",
+	"bdat", "bloc", "bsln", "cvar", "fdsc", "feat", "fmtx", "fvar",
+	"gvar", "hsty", "just", "lcar", "mort", "morx", "opbd", "prop",
+	"trak", "Zapf", "Silf", "Glat", "Gloc", "Feat", "Sill",
+}
+
+type header struct {
+	flavor              uint32
+	length              uint32
+	numTables           uint16
+	totalSfntSize       uint32
+	totalCompressedSize uint32
+	metaOffset          uint32
+	metaLength          uint32
+	metaOrigLength      uint32
+	privOffset          uint32
+	privLength          uint32
+}
+
+type tableEntry struct {
+	tag             string
+	origLength      uint32
+	transformLength uint32
+	hasTransform    bool
+}
+
+// sfntTable is a decoded table ready to be written into the rebuilt font.
+// transformed records whether data is still in its WOFF2-transformed form
+// and needs untransform to reconstruct the original table.
+type sfntTable struct {
+	tag         string
+	data        []byte
+	transformed bool
+}
+
+// Decode converts a WOFF2 byte stream into a plain sfnt (TTF/OTF) byte
+// stream. Font collections (flavor "ttcf") are not supported here; see
+// package ttc for that.
+func Decode(data []byte) ([]byte, error) {
+	if len(data) < 48 {
+		return nil, errors.New("woff2: file too short")
+	}
+	if binary.BigEndian.Uint32(data[0:4]) != signature {
+		return nil, errors.New("woff2: bad signature")
+	}
+
+	h := header{
+		flavor:              binary.BigEndian.Uint32(data[4:8]),
+		length:              binary.BigEndian.Uint32(data[8:12]),
+		numTables:           binary.BigEndian.Uint16(data[12:14]),
+		totalSfntSize:       binary.BigEndian.Uint32(data[16:20]),
+		totalCompressedSize: binary.BigEndian.Uint32(data[20:24]),
+		metaOffset:          binary.BigEndian.Uint32(data[28:32]),
+		metaLength:          binary.BigEndian.Uint32(data[32:36]),
+		metaOrigLength:      binary.BigEndian.Uint32(data[36:40]),
+		privOffset:          binary.BigEndian.Uint32(data[40:44]),
+		privLength:          binary.BigEndian.Uint32(data[44:48]),
+	}
+	if h.flavor == 0x74746366 { // 'ttcf'
+		return nil, errors.New("woff2: font collections are not supported by Decode, see package ttc")
+	}
+
+	entries, offset, err := readTableDirectory(data[48:], int(h.numTables))
+	if err != nil {
+		return nil, err
+	}
+	offset += 48
+
+	if offset+int(h.totalCompressedSize) > len(data) {
+		return nil, errors.New("woff2: compressed data runs past end of file")
+	}
+	compressed := data[offset : offset+int(h.totalCompressedSize)]
+
+	uncompressedSize := 0
+	for _, e := range entries {
+		uncompressedSize += e.storedLength()
+	}
+	uncompressed, err := brotliDecompress(compressed, uncompressedSize)
+	if err != nil {
+		return nil, fmt.Errorf("woff2: brotli decompress: %w", err)
+	}
+
+	tables, err := sliceTables(entries, uncompressed)
+	if err != nil {
+		return nil, err
+	}
+	tables, err = untransform(tables)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildSfnt(h.flavor, tables)
+}
+
+func (e tableEntry) storedLength() int {
+	if e.hasTransform {
+		return int(e.transformLength)
+	}
+	return int(e.origLength)
+}
+
+func readTableDirectory(buf []byte, numTables int) ([]tableEntry, int, error) {
+	entries := make([]tableEntry, 0, numTables)
+	pos := 0
+	for i := 0; i < numTables; i++ {
+		if pos >= len(buf) {
+			return nil, 0, errors.New("woff2: truncated table directory")
+		}
+		flags := buf[pos]
+		pos++
+
+		tagIndex := int(flags & 0x3f)
+		var tag string
+		if tagIndex == 0x3f {
+			if pos+4 > len(buf) {
+				return nil, 0, errors.New("woff2: truncated table tag")
+			}
+			tag = string(buf[pos : pos+4])
+			pos += 4
+		} else {
+			tag = knownTags[tagIndex]
+		}
+
+		transformVersion := (flags >> 6) & 0x3
+		origLength, n, err := readUintBase128(buf[pos:])
+		if err != nil {
+			return nil, 0, err
+		}
+		pos += n
+
+		// For glyf/loca, transform version 0 means "transformed"; version 3
+		// means "null transform". For hmtx, version 1 means "transformed"
+		// (strips redundant lsb arrays); no other version is defined. Every
+		// other table only defines version 0 (null transform); reject
+		// anything else rather than silently passing transformed bytes
+		// through under the original tag.
+		hasTransform := false
+		switch tag {
+		case "glyf", "loca":
+			hasTransform = transformVersion == 0
+		case "hmtx":
+			if transformVersion > 1 {
+				return nil, 0, fmt.Errorf("woff2: hmtx table has unsupported transform version %d", transformVersion)
+			}
+			hasTransform = transformVersion == 1
+		default:
+			if transformVersion != 0 {
+				return nil, 0, fmt.Errorf("woff2: table %q has an unsupported transform version %d", tag, transformVersion)
+			}
+		}
+
+		e := tableEntry{tag: tag, origLength: origLength, hasTransform: hasTransform}
+		if hasTransform {
+			transformLength, n, err := readUintBase128(buf[pos:])
+			if err != nil {
+				return nil, 0, err
+			}
+			pos += n
+			e.transformLength = transformLength
+		}
+		entries = append(entries, e)
+	}
+	return entries, pos, nil
+}
+
+// readUintBase128 decodes a UIntBase128 value as defined by the WOFF2 spec:
+// big-endian base-128 varint, high bit set on all but the last byte, no
+// leading zero bytes, at most 5 bytes, value fits in 32 bits.
+func readUintBase128(buf []byte) (uint32, int, error) {
+	var value uint32
+	for i := 0; i < 5; i++ {
+		if i >= len(buf) {
+			return 0, 0, errors.New("woff2: truncated UIntBase128")
+		}
+		b := buf[i]
+		if i == 0 && b == 0x80 {
+			return 0, 0, errors.New("woff2: UIntBase128 has a leading zero byte")
+		}
+		if value&0xFE000000 != 0 {
+			return 0, 0, errors.New("woff2: UIntBase128 overflows uint32")
+		}
+		value = (value << 7) | uint32(b&0x7f)
+		if b&0x80 == 0 {
+			return value, i + 1, nil
+		}
+	}
+	return 0, 0, errors.New("woff2: UIntBase128 longer than 5 bytes")
+}
+
+func brotliDecompress(compressed []byte, expectedSize int) ([]byte, error) {
+	r := brotli.NewReader(bytes.NewReader(compressed))
+	out := make([]byte, 0, expectedSize)
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			out = append(out, buf[:n]...)
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		}
+		if n == 0 {
+			break
+		}
+	}
+	return out, nil
+}
+
+func sliceTables(entries []tableEntry, data []byte) ([]sfntTable, error) {
+	tables := make([]sfntTable, 0, len(entries))
+	pos := 0
+	for _, e := range entries {
+		n := e.storedLength()
+		if pos+n > len(data) {
+			return nil, fmt.Errorf("woff2: table %q runs past decompressed stream", e.tag)
+		}
+		tables = append(tables, sfntTable{tag: e.tag, data: data[pos : pos+n], transformed: e.hasTransform})
+		pos += n
+	}
+	return tables, nil
+}
+
+// buildSfnt reassembles a valid sfnt (TTF/OTF) file from decoded tables.
+func buildSfnt(flavor uint32, tables []sfntTable) ([]byte, error) {
+	out := make([]sfnt.Table, len(tables))
+	for i, t := range tables {
+		out[i] = sfnt.Table{Tag: t.tag, Data: t.data}
+	}
+	return sfnt.Build(flavor, out), nil
+}
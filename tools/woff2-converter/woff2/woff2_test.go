@@ -0,0 +1,79 @@
+package woff2
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/tspython/wcanvas/tools/woff2-converter/internal/sfnt"
+)
+
+// fixture.woff2 is a hand-built two-glyph font (see gentestdata/main.go for
+// how it was generated) exercising both defined WOFF2 table transforms: glyf
+// (a point-triplet-encoded triangle plus an empty .notdef) and hmtx (lsbs
+// for both glyphs derived from glyf's reconstructed xMin, one of them
+// additionally stored explicitly). It guards against the triplet and hmtx
+// reconstruction regressing silently.
+func TestDecodeRoundTrip(t *testing.T) {
+	data, err := os.ReadFile("testdata/fixture.woff2")
+	if err != nil {
+		t.Fatalf("reading fixture: %v", err)
+	}
+
+	out, err := Decode(data)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+
+	_, tables, err := sfnt.Parse(out)
+	if err != nil {
+		t.Fatalf("parsing decoded sfnt: %v", err)
+	}
+
+	byTag := make(map[string][]byte, len(tables))
+	for _, tb := range tables {
+		byTag[tb.Tag] = tb.Data
+	}
+
+	// Glyph 0 (.notdef) is empty and contributes no bytes; glyph 1 is a
+	// 3-point on-curve triangle (0,0)-(10,0)-(10,10), so loca has two
+	// identical zero offsets followed by the padded glyph length.
+	wantLoca := []byte{0, 0, 0, 0, 0, 10}
+	if loca := byTag["loca"]; !bytes.Equal(loca, wantLoca) {
+		t.Errorf("loca = % x, want % x", loca, wantLoca)
+	}
+
+	// Glyph 1 re-encoded in canonical glyf form: 1 contour, bbox
+	// (0,0)-(10,10), endPtsOfContours = [2], no instructions, three
+	// on-curve points whose deltas from (0,0) are (0,0), (10,0), (0,10).
+	wantGlyf := []byte{
+		0, 1, // numberOfContours
+		0, 0, 0, 0, 0, 10, 0, 10, // xMin, yMin, xMax, yMax
+		0, 2, // endPtsOfContours[0]
+		0, 0, // instructionLength
+		0x31, 0x33, 0x35, // flags: on-curve + same/short-x/short-y per point
+		10, // x delta (point 1)
+		10, // y delta (point 2)
+		0,  // padding to a 4-byte boundary
+	}
+	if glyf := byTag["glyf"]; !bytes.Equal(glyf, wantGlyf) {
+		t.Errorf("glyf = % x, want % x", glyf, wantGlyf)
+	}
+
+	// hmtx: glyph 0's lsb is omitted and reconstructed from its xMin (0,
+	// since it's an empty glyph); glyph 1's lsb is stored explicitly.
+	wantHmtx := []byte{
+		2, 88, // glyph 0 advanceWidth = 600
+		0, 0, // glyph 0 lsb = xMin[0] = 0
+		0, 15, // glyph 1 lsb = 15
+	}
+	if hmtx := byTag["hmtx"]; !bytes.Equal(hmtx, wantHmtx) {
+		t.Errorf("hmtx = % x, want % x", hmtx, wantHmtx)
+	}
+
+	// maxp isn't glyf/loca/hmtx, so it must pass through untouched.
+	wantMaxp := []byte{0, 1, 0, 0, 0, 2, 0, 0}
+	if maxp := byTag["maxp"]; !bytes.Equal(maxp, wantMaxp) {
+		t.Errorf("maxp (untransformed passthrough) = % x, want % x", maxp, wantMaxp)
+	}
+}